@@ -0,0 +1,41 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// API holds the dependencies handlers need, so routes are plain methods
+// with injected dependencies instead of closures over package-level
+// globals.
+type API struct {
+	db        *gorm.DB
+	gateway   PaymentGateway
+	loanLocks sync.Map // LoanID (string) -> *sync.Mutex
+}
+
+func NewAPI(db *gorm.DB, gateway PaymentGateway) *API {
+	return &API{db: db, gateway: gateway}
+}
+
+func (api *API) Routes() *gin.Engine {
+	r := gin.Default()
+
+	r.GET("/healthz", api.Healthz)
+	r.POST("/auth/register", api.Register)
+	r.POST("/auth/login", api.Login)
+	r.POST("/payment/callback", api.PaymentCallback)
+
+	loans := r.Group("/loans", AuthRequired())
+	loans.POST("", api.RequireRole(RoleFieldOfficer, RoleAdmin), api.CreateLoan)
+	loans.GET("", api.RequireRole(RoleAdmin), api.ListLoans)
+	loans.GET("/total", api.RequireRole(RoleAdmin), api.TotalLoans)
+	loans.GET("/:loanId/outstanding", api.Outstanding)
+	loans.GET("/:loanId/delinquent", api.DelinquentStatus)
+	loans.GET("/:loanId/schedule", api.Schedule)
+	loans.POST("/:loanId/payment", api.RequireRole(RoleBorrower, RoleFieldOfficer, RoleAdmin), api.Pay)
+
+	return r
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserClaims is the JWT payload identifying the caller and their role.
+type UserClaims struct {
+	ID   string `json:"id"`
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// jwtSecret fails closed: an unset JWT_SECRET is a misconfiguration, not
+// a reason to sign and verify tokens with a hardcoded key.
+func jwtSecret() []byte {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		panic("JWT_SECRET must be set")
+	}
+	return []byte(secret)
+}
+
+// hmacKeyFunc is the jwt.Keyfunc used to verify tokens. It rejects any
+// token not signed with an HMAC algorithm so a forged token can't use
+// "none" or an asymmetric algorithm to bypass the shared secret check
+// (the classic JWT algorithm-confusion attack).
+func hmacKeyFunc(t *jwt.Token) (interface{}, error) {
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+	return jwtSecret(), nil
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+func signToken(user *User) (string, error) {
+	claims := UserClaims{
+		ID:   user.Username,
+		Role: user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret())
+}
+
+// parseBearerClaims extracts and validates the Authorization: Bearer
+// token on c, independent of whether the route requires one. Handlers
+// that are reachable without AuthRequired but still need to recognize an
+// optional caller (e.g. Register granting elevated roles) can call this
+// directly.
+func parseBearerClaims(c *gin.Context) (*UserClaims, error) {
+	header := c.GetHeader("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, errors.New("missing bearer token")
+	}
+	raw := strings.TrimPrefix(header, "Bearer ")
+
+	claims := &UserClaims{}
+	token, err := jwt.ParseWithClaims(raw, claims, hmacKeyFunc)
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	return claims, nil
+}
+
+// AuthRequired parses the Authorization: Bearer token and stores the
+// resulting *UserClaims in the request context under "claims".
+func AuthRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := parseBearerClaims(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
+
+func currentClaims(c *gin.Context) *UserClaims {
+	value, ok := c.Get("claims")
+	if !ok {
+		return nil
+	}
+	claims, ok := value.(*UserClaims)
+	if !ok {
+		return nil
+	}
+	return claims
+}
+
+// RequireRole aborts with 403 unless the authenticated caller has one of
+// the given roles. It must run after AuthRequired.
+func (api *API) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := currentClaims(c)
+		if claims == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+		for _, role := range roles {
+			if claims.Role == role {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient role"})
+	}
+}
+
+// canAccessLoan reports whether the caller may read/pay the given loan:
+// field officers and admins can touch any loan, borrowers only their own.
+func canAccessLoan(claims *UserClaims, loan *Loan) bool {
+	if claims.Role == RoleFieldOfficer || claims.Role == RoleAdmin {
+		return true
+	}
+	return claims.Role == RoleBorrower && claims.ID == loan.BorrowerID
+}
+
+// Register creates a new account. Self-service registration is only
+// allowed for borrowers; creating a field_officer or admin account
+// requires the request to already be authenticated as an admin, so a
+// caller can't grant themselves elevated access by registering.
+func (api *API) Register(c *gin.Context) {
+	var body struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Role     string `json:"role"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	switch body.Role {
+	case RoleBorrower:
+	case RoleFieldOfficer, RoleAdmin:
+		claims, err := parseBearerClaims(c)
+		if err != nil || claims.Role != RoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only an admin can create field_officer or admin accounts"})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be one of borrower, field_officer, admin"})
+		return
+	}
+
+	hash, err := hashPassword(body.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := User{Username: body.Username, PasswordHash: hash, Role: body.Role}
+	if result := api.db.Create(&user); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"username": user.Username, "role": user.Role})
+}
+
+func (api *API) Login(c *gin.Context) {
+	var body struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user User
+	if result := api.db.Where("username = ?", body.Username).First(&user); result.Error != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(body.Password)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
+
+	token, err := signToken(&user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
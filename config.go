@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config controls which database backend the service talks to and how
+// its connection pool is sized. Every field can be overridden by an
+// environment variable so the same binary can target sqlite in
+// development and mysql/postgres in production without a rebuild.
+type Config struct {
+	DBDriver        string // sqlite, mysql, or postgres
+	DBDSN           string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+func loadConfig() Config {
+	cfg := Config{
+		DBDriver:        "sqlite",
+		DBDSN:           "loans.db",
+		MaxOpenConns:    10,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: time.Hour,
+	}
+
+	if v := os.Getenv("DB_DRIVER"); v != "" {
+		cfg.DBDriver = v
+	}
+	if v := os.Getenv("DB_DSN"); v != "" {
+		cfg.DBDSN = v
+	}
+	if v := os.Getenv("DB_MAX_OPEN_CONNS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxOpenConns = n
+		}
+	}
+	if v := os.Getenv("DB_MAX_IDLE_CONNS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxIdleConns = n
+		}
+	}
+	if v := os.Getenv("DB_CONN_MAX_LIFETIME"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.ConnMaxLifetime = d
+		}
+	}
+
+	return cfg
+}
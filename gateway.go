@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// PaymentGateway initiates and verifies real-world loan payment
+// settlements on behalf of the /loans/:loanId/payment flow.
+type PaymentGateway interface {
+	// Initiate starts a transaction for amount against loanID. A
+	// non-empty redirectURL means the caller must send the borrower
+	// there to complete payment out of band (e.g. a hosted checkout
+	// page); an empty redirectURL means the gateway settles
+	// synchronously and Verify can be called right away.
+	Initiate(loanID string, amount float64) (redirectURL, gatewayTxID string, err error)
+	// Verify polls the gateway for the current status of a transaction.
+	Verify(gatewayTxID string) (success bool, err error)
+	// VerifyCallback authenticates a webhook payload and extracts the
+	// gateway transaction ID and the outcome it reports.
+	VerifyCallback(body []byte, signature string) (gatewayTxID string, success bool, err error)
+}
+
+// ManualGateway records payments taken out-of-band (cash, bank transfer
+// confirmed by a field officer) and settles them immediately. It is the
+// default gateway when no real payment processor is configured.
+type ManualGateway struct {
+	counter uint64
+}
+
+func NewManualGateway() *ManualGateway {
+	return &ManualGateway{}
+}
+
+func (g *ManualGateway) Initiate(loanID string, amount float64) (string, string, error) {
+	id := atomic.AddUint64(&g.counter, 1)
+	return "", fmt.Sprintf("manual-%s-%d", loanID, id), nil
+}
+
+func (g *ManualGateway) Verify(gatewayTxID string) (bool, error) {
+	return true, nil
+}
+
+func (g *ManualGateway) VerifyCallback(body []byte, signature string) (string, bool, error) {
+	return "", false, errors.New("manual gateway does not accept callbacks")
+}
+
+// IDPayGateway talks to an IDPay-style hosted payment API: Initiate
+// creates a payment request and returns the hosted page to redirect the
+// borrower to, and VerifyCallback authenticates the webhook the gateway
+// sends once the borrower completes (or abandons) that page.
+type IDPayGateway struct {
+	APIKey      string
+	CallbackURL string
+	BaseURL     string // e.g. https://api.idpay.ir/v1.1/payment
+	HTTPClient  *http.Client
+}
+
+func NewIDPayGateway(apiKey, callbackURL string) *IDPayGateway {
+	return &IDPayGateway{
+		APIKey:      apiKey,
+		CallbackURL: callbackURL,
+		BaseURL:     "https://api.idpay.ir/v1.1/payment",
+		HTTPClient:  &http.Client{},
+	}
+}
+
+type idpayCreateRequest struct {
+	OrderID  string `json:"order_id"`
+	Amount   int64  `json:"amount"`
+	Callback string `json:"callback"`
+}
+
+type idpayCreateResponse struct {
+	ID    string `json:"id"`
+	Link  string `json:"link"`
+	Error string `json:"error_message,omitempty"`
+}
+
+func (g *IDPayGateway) Initiate(loanID string, amount float64) (string, string, error) {
+	reqBody, err := json.Marshal(idpayCreateRequest{
+		OrderID:  fmt.Sprintf("%s-%d", loanID, int64(amount*100)),
+		Amount:   int64(amount),
+		Callback: g.CallbackURL,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, g.BaseURL, bytes.NewReader(reqBody))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-KEY", g.APIKey)
+
+	resp, err := g.HTTPClient.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	var parsed idpayCreateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return "", "", err
+	}
+	if parsed.Error != "" {
+		return "", "", errors.New(parsed.Error)
+	}
+
+	return parsed.Link, parsed.ID, nil
+}
+
+func (g *IDPayGateway) Verify(gatewayTxID string) (bool, error) {
+	reqBody, err := json.Marshal(struct {
+		ID string `json:"id"`
+	}{ID: gatewayTxID})
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, g.BaseURL+"/verify", bytes.NewReader(reqBody))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-KEY", g.APIKey)
+
+	resp, err := g.HTTPClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	var parsed struct {
+		Status int `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return false, err
+	}
+	// IDPay reports status 100 for a verified, settled transaction.
+	return parsed.Status == 100, nil
+}
+
+func (g *IDPayGateway) VerifyCallback(body []byte, signature string) (string, bool, error) {
+	mac := hmac.New(sha256.New, []byte(g.APIKey))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return "", false, errors.New("invalid callback signature")
+	}
+
+	var payload struct {
+		ID     string `json:"id"`
+		Status int    `json:"status"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", false, err
+	}
+
+	return payload.ID, payload.Status == 100, nil
+}
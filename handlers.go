@@ -0,0 +1,270 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (api *API) CreateLoan(c *gin.Context) {
+	var loanData struct {
+		BorrowerID      string  `json:"borrowerId"`
+		PrincipalAmount float64 `json:"principalAmount"`
+		InterestRate    float64 `json:"interestRate"`
+		TermWeeks       int     `json:"termWeeks"`
+	}
+	if err := c.BindJSON(&loanData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loanID := uuid.New().String()
+	loan := NewLoan(loanID, loanData.BorrowerID, loanData.PrincipalAmount, loanData.InterestRate, loanData.TermWeeks)
+
+	// Create persists loan.Payments as well via the has-many association;
+	// inserting them again here would conflict on their now-assigned PKs.
+	result := api.db.Create(loan)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, loan)
+}
+
+// loadLoan fetches the loan by its LoanID and checks that claims is
+// allowed to access it, writing the appropriate error response itself.
+// It returns ok=false if the handler should stop.
+func (api *API) loadLoan(c *gin.Context) (*Loan, bool) {
+	loanID := c.Param("loanId")
+
+	var loan Loan
+	result := api.db.Preload("Payments").Where("loan_id = ?", loanID).First(&loan)
+	if result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Loan not found"})
+		return nil, false
+	}
+
+	claims := currentClaims(c)
+	if claims == nil || !canAccessLoan(claims, &loan) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient role"})
+		return nil, false
+	}
+
+	return &loan, true
+}
+
+func (api *API) Outstanding(c *gin.Context) {
+	loan, ok := api.loadLoan(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"outstandingAmount": loan.GetOutstanding()})
+}
+
+func (api *API) DelinquentStatus(c *gin.Context) {
+	loan, ok := api.loadLoan(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"delinquent": loan.IsDelinquent()})
+}
+
+func (api *API) Schedule(c *gin.Context) {
+	loan, ok := api.loadLoan(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"schedule": loan.Payments})
+}
+
+// Pay starts a gateway transaction for the given amount and returns where
+// (if anywhere) the borrower must go to complete it. The loan itself is
+// only updated once the gateway confirms the transaction, either
+// synchronously here (gateways that settle immediately) or later via
+// PaymentCallback.
+func (api *API) Pay(c *gin.Context) {
+	loan, ok := api.loadLoan(c)
+	if !ok {
+		return
+	}
+
+	var paymentData struct {
+		Amount float64 `json:"amount"`
+	}
+	if err := c.BindJSON(&paymentData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if paymentData.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
+		return
+	}
+
+	var paymentID uint
+	if next := loan.FirstUnpaid(); next != nil {
+		paymentID = next.ID
+	}
+
+	tx := Transaction{
+		LoanID:    loan.LoanID,
+		PaymentID: paymentID,
+		Amount:    paymentData.Amount,
+		Status:    TransactionPending,
+		// Placeholder until the gateway assigns its own ID below: the
+		// column is unique, so two pending transactions can never both
+		// insert with an empty GatewayTxID.
+		GatewayTxID: uuid.New().String(),
+	}
+	if result := api.db.Create(&tx); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	redirectURL, gatewayTxID, err := api.gateway.Initiate(loan.LoanID, paymentData.Amount)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	tx.GatewayTxID = gatewayTxID
+	if result := api.db.Save(&tx); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if redirectURL == "" {
+		// The gateway settles synchronously, so we can verify and apply
+		// the payment right away instead of waiting for a callback.
+		if success, err := api.gateway.Verify(gatewayTxID); err == nil && success {
+			api.confirmTransaction(&tx)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"redirect_url": redirectURL, "payment_id": tx.ID})
+}
+
+// confirmTransaction applies a transaction's amount to its loan and marks
+// it confirmed. It is idempotent: calling it on an already-confirmed
+// transaction is a no-op, which makes duplicate gateway callbacks safe.
+//
+// Concurrent payments on the same loan are serialized twice over: an
+// in-process mutex keyed by LoanID short-circuits the common case of
+// racing requests before they ever reach the database, and the loan row
+// is additionally locked with SELECT ... FOR UPDATE inside a DB
+// transaction so MakePayment's read-modify-write is atomic even across
+// separate processes.
+func (api *API) confirmTransaction(tx *Transaction) error {
+	return api.withLoanLock(tx.LoanID, func() error {
+		return api.db.Transaction(func(dbtx *gorm.DB) error {
+			var freshTx Transaction
+			if err := dbtx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", tx.ID).First(&freshTx).Error; err != nil {
+				return err
+			}
+			if freshTx.Status == TransactionConfirmed {
+				*tx = freshTx
+				return nil
+			}
+
+			var loan Loan
+			if err := dbtx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Payments").Where("loan_id = ?", freshTx.LoanID).First(&loan).Error; err != nil {
+				return err
+			}
+
+			loan.MakePayment(dbtx, freshTx.Amount)
+
+			now := time.Now()
+			freshTx.Status = TransactionConfirmed
+			freshTx.ConfirmedAt = &now
+			if err := dbtx.Save(&freshTx).Error; err != nil {
+				return err
+			}
+
+			*tx = freshTx
+			return nil
+		})
+	})
+}
+
+// PaymentCallback is the webhook a PaymentGateway calls once a borrower
+// completes (or abandons) an out-of-band payment. It authenticates the
+// payload, then looks up the matching pending Transaction so repeated
+// callbacks for the same gateway transaction only apply once.
+func (api *API) PaymentCallback(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	signature := c.GetHeader("X-Signature")
+
+	gatewayTxID, success, err := api.gateway.VerifyCallback(body, signature)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var tx Transaction
+	if result := api.db.Where("gateway_tx_id = ?", gatewayTxID).First(&tx); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+		return
+	}
+
+	if tx.Status == TransactionConfirmed {
+		c.JSON(http.StatusOK, gin.H{"status": TransactionConfirmed})
+		return
+	}
+
+	if !success {
+		tx.Status = TransactionFailed
+		api.db.Save(&tx)
+		c.JSON(http.StatusOK, gin.H{"status": TransactionFailed})
+		return
+	}
+
+	if err := api.confirmTransaction(&tx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": TransactionConfirmed})
+}
+
+// Healthz reports whether the configured database is reachable, for use
+// by deployment health checks.
+func (api *API) Healthz(c *gin.Context) {
+	sqlDB, err := api.db.DB()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "down", "error": err.Error()})
+		return
+	}
+	if err := sqlDB.Ping(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "down", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (api *API) TotalLoans(c *gin.Context) {
+	var count int64
+	result := api.db.Model(&Loan{}).Count(&count)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"totalLoans": count})
+}
+
+func (api *API) ListLoans(c *gin.Context) {
+	var loans []Loan
+	result := api.db.Preload("Payments").Find(&loans)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"loans": loans})
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// amortizedPayment returns the level weekly installment for a loan of the
+// given principal, weekly interest rate and term, using the standard
+// annuity formula. A zero rate falls back to an even split of principal.
+func amortizedPayment(principal, weeklyRate float64, termWeeks int) float64 {
+	if termWeeks <= 0 {
+		return 0
+	}
+	if weeklyRate == 0 {
+		return principal / float64(termWeeks)
+	}
+	factor := math.Pow(1+weeklyRate, float64(termWeeks))
+	return principal * weeklyRate * factor / (factor - 1)
+}
+
+// buildSchedule amortizes principal over termWeeks at weeklyRate, starting
+// at startWeek (1-indexed) and startDate, splitting each installment into
+// its principal and interest portions. The final installment absorbs any
+// rounding remainder so the balance lands exactly on zero.
+func buildSchedule(loanID string, principal, weeklyRate float64, termWeeks, startWeek int, startDate time.Time) []Payment {
+	if termWeeks <= 0 {
+		return nil
+	}
+	installment := amortizedPayment(principal, weeklyRate, termWeeks)
+	payments := make([]Payment, termWeeks)
+	balance := principal
+
+	for i := 0; i < termWeeks; i++ {
+		interest := balance * weeklyRate
+		principalPortion := installment - interest
+		amount := installment
+		if i == termWeeks-1 {
+			// Absorb rounding drift on the last installment.
+			principalPortion = balance
+			amount = principalPortion + interest
+		}
+		balance -= principalPortion
+
+		payments[i] = Payment{
+			LoanID:           loanID,
+			Week:             startWeek + i,
+			DueDate:          startDate.AddDate(0, 0, 7*(startWeek+i)),
+			Amount:           amount,
+			PrincipalPortion: principalPortion,
+			InterestPortion:  interest,
+			Paid:             false,
+		}
+	}
+
+	return payments
+}
+
+func NewLoan(loanID, borrowerID string, principalAmount, interestRate float64, termWeeks int) *Loan {
+	startDate := time.Now()
+	payments := buildSchedule(loanID, principalAmount, interestRate, termWeeks, 1, startDate)
+	weeklyPayment := amortizedPayment(principalAmount, interestRate, termWeeks)
+
+	return &Loan{
+		LoanID:              loanID,
+		BorrowerID:          borrowerID,
+		PrincipalAmount:     principalAmount,
+		InterestRate:        interestRate,
+		TermWeeks:           termWeeks,
+		StartDate:           startDate,
+		WeeklyPaymentAmount: weeklyPayment,
+		OutstandingAmount:   principalAmount,
+		Payments:            payments,
+		Delinquent:          false,
+	}
+}
+
+func (loan *Loan) GetOutstanding() float64 {
+	return loan.OutstandingAmount
+}
+
+// FirstUnpaid returns the earliest unpaid installment, or nil if the loan
+// is fully paid off.
+func (loan *Loan) FirstUnpaid() *Payment {
+	for i := range loan.Payments {
+		if !loan.Payments[i].Paid {
+			return &loan.Payments[i]
+		}
+	}
+	return nil
+}
+
+// IsDelinquent reports whether the loan has any unpaid installment whose
+// due date is more than DelinquencyGraceDays in the past.
+func (loan *Loan) IsDelinquent() bool {
+	now := time.Now()
+	for i := range loan.Payments {
+		p := &loan.Payments[i]
+		if p.Paid {
+			continue
+		}
+		if now.Sub(p.DueDate) > DelinquencyGraceDays*24*time.Hour {
+			loan.Delinquent = true
+			return true
+		}
+	}
+	loan.Delinquent = false
+	return false
+}
+
+// MakePayment allocates amount against the loan's earliest unpaid
+// installments, paying each one off in full in order for as long as
+// amount covers it. There is no partial-installment credit: the first
+// leftover amount that can't cover the next unpaid installment — whether
+// that's a small underpayment or an overpayment past the last
+// installment it paid off — is applied straight to principal as a
+// prepayment, and the remaining unpaid schedule is re-amortized over the
+// lower balance.
+func (loan *Loan) MakePayment(db *gorm.DB, amount float64) {
+	now := time.Now()
+	remaining := amount
+
+	firstUnpaid := -1
+	for i := range loan.Payments {
+		if !loan.Payments[i].Paid {
+			firstUnpaid = i
+			break
+		}
+	}
+	if firstUnpaid == -1 {
+		return
+	}
+
+	for i := firstUnpaid; i < len(loan.Payments) && remaining > 0; i++ {
+		p := &loan.Payments[i]
+		if p.Paid {
+			continue
+		}
+		if remaining+1e-9 < p.Amount {
+			break
+		}
+		remaining -= p.Amount
+		p.Paid = true
+		paidAt := now
+		p.PaidDate = &paidAt
+		loan.OutstandingAmount -= p.PrincipalPortion
+		db.Save(p)
+	}
+
+	if remaining > 1e-9 {
+		// Prepayment: apply straight to principal and re-derive the
+		// installments that haven't been paid yet.
+		loan.OutstandingAmount -= remaining
+		if loan.OutstandingAmount < 0 {
+			loan.OutstandingAmount = 0
+		}
+		if loan.OutstandingAmount <= 1e-9 {
+			loan.settleRemaining(db, now)
+		} else {
+			loan.reamortizeUnpaid(db)
+		}
+	}
+
+	db.Save(loan)
+	loan.IsDelinquent()
+}
+
+// settleRemaining marks every still-unpaid installment paid with a zero
+// amount. Called once a prepayment has driven OutstandingAmount to zero:
+// re-amortizing a $0 balance would otherwise leave a zero-amount unpaid
+// installment on the books, which IsDelinquent would then flag as overdue
+// on a loan that's actually fully paid off.
+func (loan *Loan) settleRemaining(db *gorm.DB, at time.Time) {
+	for i := range loan.Payments {
+		p := &loan.Payments[i]
+		if p.Paid {
+			continue
+		}
+		p.Paid = true
+		paidAt := at
+		p.PaidDate = &paidAt
+		p.Amount = 0
+		p.PrincipalPortion = 0
+		p.InterestPortion = 0
+		db.Save(p)
+	}
+}
+
+// reamortizeUnpaid recomputes amount/principal/interest for every unpaid
+// installment using the loan's current outstanding balance, keeping each
+// installment's original week and due date.
+func (loan *Loan) reamortizeUnpaid(db *gorm.DB) {
+	var unpaidIdx []int
+	for i := range loan.Payments {
+		if !loan.Payments[i].Paid {
+			unpaidIdx = append(unpaidIdx, i)
+		}
+	}
+	if len(unpaidIdx) == 0 {
+		return
+	}
+
+	fresh := buildSchedule(loan.LoanID, loan.OutstandingAmount, loan.InterestRate, len(unpaidIdx), loan.Payments[unpaidIdx[0]].Week, loan.StartDate)
+	for i, idx := range unpaidIdx {
+		due := loan.Payments[idx].DueDate
+		id := loan.Payments[idx].ID
+		owner := loan.Payments[idx].LoanID
+		loan.Payments[idx] = fresh[i]
+		loan.Payments[idx].ID = id
+		loan.Payments[idx].LoanID = owner
+		loan.Payments[idx].DueDate = due
+		db.Save(&loan.Payments[idx])
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import "sync"
+
+// loanMutex returns the in-process mutex for loanID, creating one on
+// first use. This lets concurrent requests for the same loan queue up
+// before they ever touch the database, so the common case of duplicate
+// or racing payment requests doesn't rely solely on row locking.
+func (api *API) loanMutex(loanID string) *sync.Mutex {
+	value, _ := api.loanLocks.LoadOrStore(loanID, &sync.Mutex{})
+	return value.(*sync.Mutex)
+}
+
+// withLoanLock serializes fn against other callers for the same loanID.
+func (api *API) withLoanLock(loanID string, fn func() error) error {
+	mu := api.loanMutex(loanID)
+	mu.Lock()
+	defer mu.Unlock()
+	return fn()
+}
@@ -0,0 +1,70 @@
+package main
+
+import "time"
+
+// DelinquencyGraceDays is how many days past an installment's due date are
+// tolerated before a loan is flagged delinquent.
+const DelinquencyGraceDays = 7
+
+// Role values stored on User and embedded in UserClaims.
+const (
+	RoleBorrower     = "borrower"
+	RoleFieldOfficer = "field_officer"
+	RoleAdmin        = "admin"
+)
+
+type Loan struct {
+	ID                  uint   `gorm:"primaryKey"`
+	LoanID              string `gorm:"unique"`
+	BorrowerID          string
+	PrincipalAmount     float64
+	InterestRate        float64 // weekly interest rate, e.g. 0.01 for 1%/week
+	TermWeeks           int
+	StartDate           time.Time
+	WeeklyPaymentAmount float64
+	OutstandingAmount   float64
+	Payments            []Payment `gorm:"foreignKey:LoanID"`
+	Delinquent          bool
+}
+
+type Payment struct {
+	ID               uint `gorm:"primaryKey"`
+	LoanID           string
+	Week             int
+	DueDate          time.Time
+	Amount           float64
+	PrincipalPortion float64
+	InterestPortion  float64
+	Paid             bool
+	PaidDate         *time.Time
+}
+
+// User is an account holder: a borrower, a field officer who creates loans
+// and records payments on their behalf, or an admin.
+type User struct {
+	ID           uint   `gorm:"primaryKey"`
+	Username     string `gorm:"unique"`
+	PasswordHash string
+	Role         string
+}
+
+// Transaction statuses.
+const (
+	TransactionPending   = "pending"
+	TransactionConfirmed = "confirmed"
+	TransactionFailed    = "failed"
+)
+
+// Transaction links a PaymentGateway's transaction ID to the installment
+// it settles, so a gateway callback can be applied exactly once even if
+// it is delivered more than once.
+type Transaction struct {
+	ID          uint   `gorm:"primaryKey"`
+	GatewayTxID string `gorm:"unique"`
+	LoanID      string
+	PaymentID   uint
+	Amount      float64
+	Status      string
+	CreatedAt   time.Time
+	ConfirmedAt *time.Time
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func newTestAPI(t *testing.T) *API {
+	t.Helper()
+	db, err := openDB(Config{DBDriver: "sqlite", DBDSN: "file::memory:?cache=shared", MaxOpenConns: 10, MaxIdleConns: 5})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := migrateDB(db); err != nil {
+		t.Fatalf("failed to migrate test db: %v", err)
+	}
+	return NewAPI(db, NewManualGateway())
+}
+
+// TestConcurrentPaymentsApplyOncePerWeek fires one confirmTransaction per
+// unpaid installment, all for the same loan and all at once, and asserts
+// that every installment is paid exactly once instead of being skipped or
+// double-applied by a lost update.
+func TestConcurrentPaymentsApplyOncePerWeek(t *testing.T) {
+	api := newTestAPI(t)
+
+	loan := NewLoan("loan-concurrency", "borrower-1", 1000, 0.01, 4)
+	if err := api.db.Create(loan).Error; err != nil {
+		t.Fatalf("failed to create loan: %v", err)
+	}
+
+	amount := loan.Payments[0].Amount
+	concurrency := len(loan.Payments)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		tx := Transaction{
+			LoanID:      loan.LoanID,
+			Amount:      amount,
+			Status:      TransactionPending,
+			GatewayTxID: fmt.Sprintf("test-tx-%d", i),
+		}
+		if err := api.db.Create(&tx).Error; err != nil {
+			t.Fatalf("failed to create transaction: %v", err)
+		}
+
+		wg.Add(1)
+		go func(tx Transaction) {
+			defer wg.Done()
+			if err := api.confirmTransaction(&tx); err != nil {
+				t.Errorf("confirmTransaction: %v", err)
+			}
+		}(tx)
+	}
+	wg.Wait()
+
+	var reloaded Loan
+	if err := api.db.Preload("Payments").Where("loan_id = ?", loan.LoanID).First(&reloaded).Error; err != nil {
+		t.Fatalf("failed to reload loan: %v", err)
+	}
+
+	paidCount := 0
+	for _, p := range reloaded.Payments {
+		if p.Paid {
+			paidCount++
+		}
+	}
+	if paidCount != concurrency {
+		t.Fatalf("expected exactly %d installments paid after %d concurrent identical payments, got %d", concurrency, concurrency, paidCount)
+	}
+	if reloaded.OutstandingAmount > 1e-6 {
+		t.Fatalf("expected loan fully paid off, outstanding amount is %f", reloaded.OutstandingAmount)
+	}
+}